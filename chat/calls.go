@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"github.com/habrachat/ssh-chat/chat/message"
+)
+
+// Resolver looks up a room member by the name they typed, e.g. to
+// "/call bob". It's supplied by the room, which owns the member roster.
+type Resolver func(name string) (*message.User, bool)
+
+// Calls is the room-level home for chat/message's CallTable: it resolves
+// the CallRequestMsg and HangupMsg that message.ParseInput produces from
+// /call and /hangup into actual CallOfferMsg and CallTerminateMsg
+// signaling, the way Messages is the room-level home for MessageStore.
+type Calls struct {
+	table *message.CallTable
+}
+
+// NewCalls creates an empty call dispatcher.
+func NewCalls() *Calls {
+	return &Calls{table: message.NewCallTable()}
+}
+
+// Handle resolves cmd into the signaling message to relay. For a
+// CallRequestMsg it resolves the target name via resolve, allocates a
+// CallID, and starts it in the CallTable, timing it out after
+// message.CallTimeout if unanswered. For a HangupMsg it ends whichever
+// call the sender is currently a party to. It returns ok=false if cmd
+// isn't a CallRequestMsg or HangupMsg, if a /call target can't be
+// resolved, if either party is already on a call, or if a /hangup sender
+// isn't on one.
+func (c *Calls) Handle(cmd message.Message, resolve Resolver) (message.Message, bool) {
+	switch m := cmd.(type) {
+	case *message.CallRequestMsg:
+		to, ok := resolve(m.TargetName())
+		if !ok {
+			return nil, false
+		}
+		callID := message.NextCallID()
+		onTimeout := func() { c.table.End(callID, m.From(), to) }
+		if !c.table.Start(callID, m.From(), to, onTimeout) {
+			return nil, false
+		}
+		return message.NewCallOfferMsg(callID, m.From(), to, ""), true
+	case *message.HangupMsg:
+		callID, ok := c.table.Active(m.From())
+		if !ok {
+			return nil, false
+		}
+		from, to, ok := c.table.Parties(callID)
+		if !ok {
+			return nil, false
+		}
+		other := to
+		if other == m.From() {
+			other = from
+		}
+		c.table.End(callID, from, to)
+		return message.NewCallTerminateMsg(callID, m.From(), other, "hangup"), true
+	default:
+		return nil, false
+	}
+}
+
+// Answer records that callID's offer was answered, canceling its
+// unanswered-offer timeout. The caller is responsible for constructing and
+// relaying the CallAnswerMsg itself, since unlike /call and /hangup,
+// answering happens out of band from a CallCapable client, not through
+// ParseInput.
+func (c *Calls) Answer(callID message.CallID) {
+	c.table.Answer(callID)
+}
@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/habrachat/ssh-chat/chat/message"
+)
+
+func TestCallsHandleCallRequest(t *testing.T) {
+	alice := &message.User{}
+	bob := &message.User{}
+	calls := NewCalls()
+	resolve := func(name string) (*message.User, bool) {
+		if name == "bob" {
+			return bob, true
+		}
+		return nil, false
+	}
+
+	req := message.NewCallRequestMsg("bob", alice)
+	result, ok := calls.Handle(req, resolve)
+	if !ok {
+		t.Fatalf("Handle(call request) not ok")
+	}
+	if _, ok := result.(*message.CallOfferMsg); !ok {
+		t.Fatalf("result is %T, want *message.CallOfferMsg", result)
+	}
+}
+
+func TestCallsHandleCallRequestUnresolvedTarget(t *testing.T) {
+	alice := &message.User{}
+	calls := NewCalls()
+	resolve := func(name string) (*message.User, bool) { return nil, false }
+
+	if _, ok := calls.Handle(message.NewCallRequestMsg("ghost", alice), resolve); ok {
+		t.Errorf("Handle should fail to resolve an unknown target")
+	}
+}
+
+func TestCallsHandleHangup(t *testing.T) {
+	alice := &message.User{}
+	bob := &message.User{}
+	calls := NewCalls()
+	resolve := func(name string) (*message.User, bool) { return bob, true }
+
+	if _, ok := calls.Handle(message.NewCallRequestMsg("bob", alice), resolve); !ok {
+		t.Fatalf("setup call request should succeed")
+	}
+
+	result, ok := calls.Handle(message.NewHangupMsg(alice), resolve)
+	if !ok {
+		t.Fatalf("Handle(hangup) not ok")
+	}
+	if _, ok := result.(*message.CallTerminateMsg); !ok {
+		t.Fatalf("result is %T, want *message.CallTerminateMsg", result)
+	}
+
+	if _, ok := calls.Handle(message.NewHangupMsg(alice), resolve); ok {
+		t.Errorf("a second hangup with no active call should fail")
+	}
+}
@@ -0,0 +1,142 @@
+// Package chat contains room-level state that spans multiple messages,
+// such as form sessions, built on top of the stateless chat/message types.
+package chat
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/habrachat/ssh-chat/chat/message"
+)
+
+// ErrFormRequired is returned by FormSession.Submit when a required field
+// is left blank.
+var ErrFormRequired = errors.New("a value is required")
+
+// FormSession walks one user through answering a FormMsg's fields in
+// order, then hands the collected answers to done as a FormResponseMsg.
+// This is the Ad-Hoc-command pattern: it lets a command like /register
+// collect structured input over several turns instead of requiring every
+// argument on one line.
+type FormSession struct {
+	user    *message.User
+	title   string
+	fields  []message.Field
+	answers map[string]string
+	step    int
+	done    func(*message.FormResponseMsg)
+}
+
+// NewFormSession starts a session for user that will ask fields in order
+// and call done once all of them are answered.
+func NewFormSession(user *message.User, title string, fields []message.Field, done func(*message.FormResponseMsg)) *FormSession {
+	return &FormSession{
+		user:    user,
+		title:   title,
+		fields:  fields,
+		answers: make(map[string]string, len(fields)),
+		done:    done,
+	}
+}
+
+// Next returns the prompt for the next unanswered field, or nil if the
+// form has already been completed.
+func (s *FormSession) Next() *message.FormMsg {
+	if s.step >= len(s.fields) {
+		return nil
+	}
+	return message.NewFormMsg(s.title, s.fields[s.step], s.user)
+}
+
+// Submit records the user's reply to the current field and advances the
+// session. It reports true once every field has been answered, at which
+// point done has already been called.
+func (s *FormSession) Submit(value string) (bool, error) {
+	if s.step >= len(s.fields) {
+		return true, nil
+	}
+
+	field := s.fields[s.step]
+	if field.Required && value == "" {
+		return false, ErrFormRequired
+	}
+
+	s.answers[field.Name] = value
+	s.step++
+
+	if s.step < len(s.fields) {
+		return false, nil
+	}
+
+	s.done(message.NewFormResponseMsg(s.title, s.answers, s.user))
+	return true, nil
+}
+
+// FormSessions tracks the at-most-one active FormSession per user, and is
+// the chat package's entry point for routing a user's ordinary input into
+// it instead of room speech.
+type FormSessions struct {
+	sync.Mutex
+	byUser map[*message.User]*FormSession
+}
+
+// NewFormSessions creates an empty session registry.
+func NewFormSessions() *FormSessions {
+	return &FormSessions{
+		byUser: make(map[*message.User]*FormSession),
+	}
+}
+
+// Start registers s as the active session for its user, replacing any
+// previous one, and returns its first prompt.
+func (fs *FormSessions) Start(s *FormSession) *message.FormMsg {
+	fs.Lock()
+	fs.byUser[s.user] = s
+	fs.Unlock()
+	return s.Next()
+}
+
+// Active returns the in-progress session for u, if any.
+func (fs *FormSessions) Active(u *message.User) (*FormSession, bool) {
+	fs.Lock()
+	defer fs.Unlock()
+	s, ok := fs.byUser[u]
+	return s, ok
+}
+
+// Cancel aborts u's active session, if any, e.g. in response to /cancel.
+func (fs *FormSessions) Cancel(u *message.User) {
+	fs.Lock()
+	defer fs.Unlock()
+	delete(fs.byUser, u)
+}
+
+// ParseInput is the chat package's entry point for user text. If u has an
+// active form session, body is treated as that form's next answer (or
+// /cancel to abort it) rather than being parsed as room speech or a
+// command, and the Message returned is the one to send back to u, which may
+// be nil if nothing needs to be echoed. Without an active session it returns
+// message.ParseInput's result unchanged.
+func (fs *FormSessions) ParseInput(body string, from, originalFrom *message.User) message.Message {
+	s, active := fs.Active(from)
+	if !active {
+		return message.ParseInput(body, from, originalFrom)
+	}
+
+	if body == "/cancel" {
+		fs.Cancel(from)
+		return message.NewSystemMsg("form cancelled", from)
+	}
+
+	done, err := s.Submit(body)
+	if err != nil {
+		return message.NewSystemMsg(err.Error(), from)
+	}
+	if done {
+		fs.Lock()
+		delete(fs.byUser, from)
+		fs.Unlock()
+		return nil
+	}
+	return s.Next()
+}
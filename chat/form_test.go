@@ -0,0 +1,177 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/habrachat/ssh-chat/chat/message"
+)
+
+func testFields() []message.Field {
+	return []message.Field{
+		{Name: "name", Label: "Name", Required: true},
+		{Name: "bio", Label: "Bio"},
+	}
+}
+
+func TestFormSessionSubmitAdvancesThroughFields(t *testing.T) {
+	alice := &message.User{}
+	var got *message.FormResponseMsg
+	s := NewFormSession(alice, "register", testFields(), func(r *message.FormResponseMsg) { got = r })
+
+	if f := s.Next(); f.Field().Name != "name" {
+		t.Fatalf("Next() = %q, want first field %q", f.Field().Name, "name")
+	}
+
+	done, err := s.Submit("Alice")
+	if err != nil || done {
+		t.Fatalf("Submit(first field) = %v, %v, want false, nil", done, err)
+	}
+	if f := s.Next(); f.Field().Name != "bio" {
+		t.Fatalf("Next() after first answer = %q, want second field %q", f.Field().Name, "bio")
+	}
+
+	done, err = s.Submit("likes Go")
+	if err != nil || !done {
+		t.Fatalf("Submit(last field) = %v, %v, want true, nil", done, err)
+	}
+	if s.Next() != nil {
+		t.Errorf("Next() after completion = %v, want nil", s.Next())
+	}
+
+	if got == nil {
+		t.Fatalf("done callback was not called")
+	}
+	if got.Values()["name"] != "Alice" || got.Values()["bio"] != "likes Go" {
+		t.Errorf("done callback got %v, want name=Alice bio=\"likes Go\"", got.Values())
+	}
+}
+
+func TestFormSessionSubmitRejectsBlankRequiredField(t *testing.T) {
+	alice := &message.User{}
+	called := false
+	s := NewFormSession(alice, "register", testFields(), func(*message.FormResponseMsg) { called = true })
+
+	done, err := s.Submit("")
+	if !errors.Is(err, ErrFormRequired) {
+		t.Fatalf("Submit(blank required) err = %v, want ErrFormRequired", err)
+	}
+	if done {
+		t.Errorf("Submit(blank required) done = true, want false")
+	}
+	if f := s.Next(); f.Field().Name != "name" {
+		t.Errorf("Next() after rejected submit = %q, want to still be on %q", f.Field().Name, "name")
+	}
+	if called {
+		t.Errorf("done callback should not run after a rejected submit")
+	}
+}
+
+func TestFormSessionSubmitAllowsBlankOptionalField(t *testing.T) {
+	alice := &message.User{}
+	s := NewFormSession(alice, "register", testFields(), func(*message.FormResponseMsg) {})
+
+	if _, err := s.Submit("Alice"); err != nil {
+		t.Fatalf("Submit(required field): %v", err)
+	}
+	done, err := s.Submit("")
+	if err != nil || !done {
+		t.Fatalf("Submit(blank optional field) = %v, %v, want true, nil", done, err)
+	}
+}
+
+func TestFormSessionSubmitPastCompletionIsANoop(t *testing.T) {
+	alice := &message.User{}
+	calls := 0
+	s := NewFormSession(alice, "register", testFields(), func(*message.FormResponseMsg) { calls++ })
+
+	s.Submit("Alice")
+	s.Submit("likes Go")
+	if calls != 1 {
+		t.Fatalf("done callback called %d times, want 1", calls)
+	}
+
+	done, err := s.Submit("ignored")
+	if err != nil || !done {
+		t.Errorf("Submit after completion = %v, %v, want true, nil", done, err)
+	}
+	if calls != 1 {
+		t.Errorf("done callback called again after completion, want still 1")
+	}
+}
+
+func TestFormSessionsStartReplacesPreviousSession(t *testing.T) {
+	alice := &message.User{}
+	fs := NewFormSessions()
+
+	first := NewFormSession(alice, "register", testFields(), func(*message.FormResponseMsg) {})
+	fs.Start(first)
+
+	second := NewFormSession(alice, "ban", []message.Field{{Name: "reason", Label: "Reason"}}, func(*message.FormResponseMsg) {})
+	prompt := fs.Start(second)
+	if prompt.Field().Name != "reason" {
+		t.Errorf("Start(second) prompt = %q, want %q", prompt.Field().Name, "reason")
+	}
+
+	active, ok := fs.Active(alice)
+	if !ok || active != second {
+		t.Errorf("Active(alice) = %v, %v, want the second session", active, ok)
+	}
+}
+
+func TestFormSessionsCancelRemovesSession(t *testing.T) {
+	alice := &message.User{}
+	fs := NewFormSessions()
+	fs.Start(NewFormSession(alice, "register", testFields(), func(*message.FormResponseMsg) {}))
+
+	fs.Cancel(alice)
+	if _, ok := fs.Active(alice); ok {
+		t.Errorf("Active(alice) after Cancel = ok, want no active session")
+	}
+}
+
+func TestFormSessionsParseInputRoutesToActiveSession(t *testing.T) {
+	alice := &message.User{}
+	fs := NewFormSessions()
+	fs.Start(NewFormSession(alice, "register", testFields(), func(*message.FormResponseMsg) {}))
+
+	reply := fs.ParseInput("Alice", alice, alice)
+	fm, ok := reply.(*message.FormMsg)
+	if !ok {
+		t.Fatalf("ParseInput(first answer) = %T, want *message.FormMsg prompting the next field", reply)
+	}
+	if fm.Field().Name != "bio" {
+		t.Errorf("ParseInput(first answer) next field = %q, want %q", fm.Field().Name, "bio")
+	}
+
+	if reply := fs.ParseInput("likes Go", alice, alice); reply != nil {
+		t.Errorf("ParseInput(last answer) = %v, want nil (nothing left to echo)", reply)
+	}
+	if _, ok := fs.Active(alice); ok {
+		t.Errorf("Active(alice) after form completion = ok, want the session to be gone")
+	}
+}
+
+func TestFormSessionsParseInputCancel(t *testing.T) {
+	alice := &message.User{}
+	fs := NewFormSessions()
+	fs.Start(NewFormSession(alice, "register", testFields(), func(*message.FormResponseMsg) {}))
+
+	reply := fs.ParseInput("/cancel", alice, alice)
+	if _, ok := reply.(*message.SystemMsg); !ok {
+		t.Fatalf("ParseInput(/cancel) = %T, want *message.SystemMsg", reply)
+	}
+	if _, ok := fs.Active(alice); ok {
+		t.Errorf("Active(alice) after /cancel = ok, want the session to be gone")
+	}
+}
+
+func TestFormSessionsParseInputFallsBackWithoutActiveSession(t *testing.T) {
+	alice := &message.User{}
+	fs := NewFormSessions()
+
+	reply := fs.ParseInput("hello", alice, alice)
+	if _, ok := reply.(message.PublicMsg); !ok {
+		t.Fatalf("ParseInput(no active session) = %T, want it to fall back to message.ParseInput", reply)
+	}
+}
@@ -0,0 +1,196 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/habrachat/ssh-chat/chat/message"
+	bolt "go.etcd.io/bbolt"
+)
+
+var historyBucket = []byte("history")
+
+// History persists public room messages in a small embedded KV store, so
+// a user who reconnects from a flaky link can backfill what they missed.
+// This is ssh-chat's analogue of XEP-0313 MAM replay used by XMPP bridges.
+type History struct {
+	db *bolt.DB
+}
+
+// NewHistory opens (creating if necessary) a History backed by a bbolt
+// file at path.
+func NewHistory(path string) (*History, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(historyBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &History{db: db}, nil
+}
+
+// Close releases the underlying KV store.
+func (h *History) Close() error {
+	return h.db.Close()
+}
+
+type historyRecord struct {
+	ID        message.ID
+	From      string
+	Body      string
+	Timestamp time.Time
+}
+
+// idKey formats id as a fixed-width, lexicographically sortable bbolt key,
+// since message IDs are assigned in increasing order.
+func idKey(id message.ID) []byte {
+	return []byte(fmt.Sprintf("%020d", uint64(id)))
+}
+
+// Append persists m for later replay.
+func (h *History) Append(m message.PublicMsg) error {
+	rec := historyRecord{
+		ID:        m.ID(),
+		From:      m.From().Name(),
+		Body:      m.Body(),
+		Timestamp: m.Timestamp(),
+	}
+	val, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(historyBucket).Put(idKey(rec.ID), val)
+	})
+}
+
+// Update rewrites the body of the persisted record for id, so a later
+// edit (EditMsg) made after a message was already persisted is reflected
+// in /history replay and join-backfill instead of showing the stale
+// original text forever. It's a no-op if id was never persisted.
+func (h *History) Update(id message.ID, body string) error {
+	return h.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket)
+		key := idKey(id)
+		val := bucket.Get(key)
+		if val == nil {
+			return nil
+		}
+		var rec historyRecord
+		if err := json.Unmarshal(val, &rec); err != nil {
+			return err
+		}
+		rec.Body = body
+		newVal, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, newVal)
+	})
+}
+
+// Delete removes the persisted record for id, so a later deletion
+// (DeleteMsg) made after a message was already persisted is reflected in
+// /history replay and join-backfill instead of showing deleted content
+// forever. It's a no-op if id was never persisted.
+func (h *History) Delete(id message.ID) error {
+	return h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(historyBucket).Delete(idKey(id))
+	})
+}
+
+// Replay returns the n most recently stored messages, oldest first,
+// wrapped in a message.HistoryMsg carrying their original send time.
+func (h *History) Replay(n int) ([]*message.HistoryMsg, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	var recs []historyRecord
+	err := h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(historyBucket).Cursor()
+		for k, v := c.Last(); k != nil && len(recs) < n; k, v = c.Prev() {
+			var rec historyRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			recs = append(recs, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wrapOldestFirst(recs), nil
+}
+
+// ReplaySince returns every stored message sent within the last since
+// duration, oldest first, wrapped in a message.HistoryMsg.
+func (h *History) ReplaySince(since time.Duration) ([]*message.HistoryMsg, error) {
+	cutoff := time.Now().Add(-since)
+
+	var recs []historyRecord
+	err := h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(historyBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var rec historyRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			if rec.Timestamp.Before(cutoff) {
+				break
+			}
+			recs = append(recs, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wrapOldestFirst(recs), nil
+}
+
+// wrapOldestFirst reverses recs (collected newest-first by cursor.Prev)
+// and wraps each as a message.HistoryMsg.
+func wrapOldestFirst(recs []historyRecord) []*message.HistoryMsg {
+	msgs := make([]*message.HistoryMsg, 0, len(recs))
+	for i := len(recs) - 1; i >= 0; i-- {
+		rec := recs[i]
+		line := message.NewHistoryLine(rec.ID, rec.From, rec.Body, rec.Timestamp)
+		msgs = append(msgs, message.NewHistoryMsg(line, rec.Timestamp))
+	}
+	return msgs
+}
+
+// ParseHistoryArgs turns the /history command's argument into either a
+// message count or a lookback duration, mirroring a MAM query's <max/> and
+// <start/> filters. With no argument it returns DefaultHistoryOnJoin.
+func ParseHistoryArgs(args []string) (count int, since time.Duration, err error) {
+	if len(args) == 0 {
+		return message.DefaultHistoryOnJoin, 0, nil
+	}
+
+	arg := args[0]
+	if rest, ok := strings.CutPrefix(arg, "since="); ok {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid /history duration: %w", err)
+		}
+		return 0, d, nil
+	}
+
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid /history count: %w", err)
+	}
+	return n, 0, nil
+}
@@ -0,0 +1,90 @@
+package chat
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/habrachat/ssh-chat/chat/message"
+)
+
+func newTestHistory(t *testing.T) *History {
+	t.Helper()
+	h, err := NewHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("NewHistory: %v", err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func TestHistoryUpdateRewritesBody(t *testing.T) {
+	h := newTestHistory(t)
+	alice := &message.User{}
+	pm := message.NewPublicMsg("original", alice, alice)
+
+	if err := h.Append(pm); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := h.Update(pm.ID(), "edited"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	msgs, err := h.Replay(1)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Replay after Update = %v, want 1 message", msgs)
+	}
+	if want := "[" + msgs[0].Delay().Format("2006-01-02 15:04") + "] : edited"; msgs[0].String() != want {
+		t.Errorf("Replay after Update = %q, want %q", msgs[0].String(), want)
+	}
+}
+
+func TestHistoryDeleteRemovesRecord(t *testing.T) {
+	h := newTestHistory(t)
+	alice := &message.User{}
+	pm := message.NewPublicMsg("gone soon", alice, alice)
+
+	if err := h.Append(pm); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := h.Delete(pm.ID()); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	msgs, err := h.Replay(10)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(msgs) != 0 {
+		t.Errorf("Replay after Delete = %v, want no messages", msgs)
+	}
+}
+
+func TestMessagesHandleEditUpdatesHistory(t *testing.T) {
+	h := newTestHistory(t)
+	alice := &message.User{}
+	ms := NewMessages(10, h)
+
+	pm := message.NewPublicMsg("hello", alice, alice)
+	ms.Record(&pm)
+	if err := h.Append(pm); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	if _, ok := ms.Handle(message.NewEditMsg(pm.ID(), "hello, edited", alice)); !ok {
+		t.Fatalf("Handle(edit) not ok")
+	}
+
+	msgs, err := h.Replay(1)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Replay after edit = %v, want 1 message", msgs)
+	}
+	if want := "[" + msgs[0].Delay().Format("2006-01-02 15:04") + "] : hello, edited"; msgs[0].String() != want {
+		t.Errorf("Replay after edit = %q, want %q", msgs[0].String(), want)
+	}
+}
@@ -0,0 +1,456 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CallID identifies one voice-call signaling session between two users.
+type CallID uint64
+
+var lastCallID uint64
+
+// NextCallID returns a new, unique CallID for a /call offer.
+func NextCallID() CallID {
+	return CallID(atomic.AddUint64(&lastCallID, 1))
+}
+
+// callMsg is embedded by the call-signaling message types. The server
+// treats the payload as an opaque blob and relays it as a private message
+// between the two participants; only a CallCapable client is expected to
+// interpret it, typically as SDP for a WebRTC/Opus session.
+type callMsg struct {
+	Msg
+	callID  CallID
+	from    *User
+	to      *User
+	payload string
+}
+
+func (m callMsg) From() *User {
+	return m.from
+}
+
+func (m callMsg) To() *User {
+	return m.to
+}
+
+// CallID returns the signaling session this message belongs to.
+func (m callMsg) CallID() CallID {
+	return m.callID
+}
+
+// Payload returns the opaque SDP-like blob carried by this message.
+func (m callMsg) Payload() string {
+	return m.payload
+}
+
+func newCallMsg(callID CallID, from, to *User, payload string) callMsg {
+	return callMsg{
+		Msg: Msg{
+			id:        NextID(),
+			timestamp: time.Now(),
+		},
+		callID:  callID,
+		from:    from,
+		to:      to,
+		payload: payload,
+	}
+}
+
+// CallOfferMsg is sent by the caller to propose a voice call, carrying the
+// initial SDP offer.
+type CallOfferMsg struct {
+	callMsg
+}
+
+func NewCallOfferMsg(callID CallID, from, to *User, sdp string) *CallOfferMsg {
+	return &CallOfferMsg{newCallMsg(callID, from, to, sdp)}
+}
+
+func (m *CallOfferMsg) Render(t *Theme) string {
+	if t == nil {
+		return m.String()
+	}
+	return t.ColorSys(m.String())
+}
+
+func (m *CallOfferMsg) String() string {
+	return fmt.Sprintf("-> %s is calling you (client does not support calls)", m.from.Name())
+}
+
+func (m *CallOfferMsg) Marshal(format OutputFormat) []byte {
+	switch format {
+	case JSON:
+		return jsonEvent{
+			ID:      m.ID(),
+			Time:    m.Timestamp().Format(time.RFC3339Nano),
+			Type:    "call-offer",
+			From:    m.from.Name(),
+			To:      m.to.Name(),
+			CallID:  m.callID,
+			Payload: m.payload,
+		}.marshal()
+	case IRCv3:
+		prefix := fmt.Sprintf("%s!%s@ssh-chat", m.from.Name(), m.from.Name())
+		return ircLine(m.ID(), m.Timestamp().Format(time.RFC3339Nano), prefix, "CALLOFFER", m.to.Name(), m.payload)
+	default:
+		return nil
+	}
+}
+
+// RenderFor renders the offer for cfg. A client that hasn't set
+// CallCapable only ever gets the plain-text "can't take this call" notice,
+// since it has no way to act on the SDP payload. A CallCapable client gets
+// cfg's structured format if it's set to one, and otherwise JSON, so it can
+// always extract CallID/Payload and establish the session.
+func (m *CallOfferMsg) RenderFor(cfg UserConfig) string {
+	if !cfg.CallCapable {
+		return m.Render(cfg.Theme)
+	}
+	if s, ok := renderStructured(m, cfg); ok {
+		return s
+	}
+	return strings.TrimRight(string(m.Marshal(JSON)), "\n")
+}
+
+// CallAnswerMsg is sent by the callee to accept a CallOfferMsg, carrying
+// the answering SDP.
+type CallAnswerMsg struct {
+	callMsg
+}
+
+func NewCallAnswerMsg(callID CallID, from, to *User, sdp string) *CallAnswerMsg {
+	return &CallAnswerMsg{newCallMsg(callID, from, to, sdp)}
+}
+
+func (m *CallAnswerMsg) Render(t *Theme) string {
+	if t == nil {
+		return m.String()
+	}
+	return t.ColorSys(m.String())
+}
+
+func (m *CallAnswerMsg) String() string {
+	return fmt.Sprintf("-> %s answered the call", m.from.Name())
+}
+
+func (m *CallAnswerMsg) Marshal(format OutputFormat) []byte {
+	switch format {
+	case JSON:
+		return jsonEvent{
+			ID:      m.ID(),
+			Time:    m.Timestamp().Format(time.RFC3339Nano),
+			Type:    "call-answer",
+			From:    m.from.Name(),
+			To:      m.to.Name(),
+			CallID:  m.callID,
+			Payload: m.payload,
+		}.marshal()
+	case IRCv3:
+		prefix := fmt.Sprintf("%s!%s@ssh-chat", m.from.Name(), m.from.Name())
+		return ircLine(m.ID(), m.Timestamp().Format(time.RFC3339Nano), prefix, "CALLANSWER", m.to.Name(), m.payload)
+	default:
+		return nil
+	}
+}
+
+// RenderFor renders the answer for cfg, following the same
+// CallCapable-gated structured/plain-text split as CallOfferMsg.RenderFor.
+func (m *CallAnswerMsg) RenderFor(cfg UserConfig) string {
+	if !cfg.CallCapable {
+		return m.Render(cfg.Theme)
+	}
+	if s, ok := renderStructured(m, cfg); ok {
+		return s
+	}
+	return strings.TrimRight(string(m.Marshal(JSON)), "\n")
+}
+
+// CallCandidateMsg carries one ICE candidate exchanged while a call is
+// being established.
+type CallCandidateMsg struct {
+	callMsg
+}
+
+func NewCallCandidateMsg(callID CallID, from, to *User, candidate string) *CallCandidateMsg {
+	return &CallCandidateMsg{newCallMsg(callID, from, to, candidate)}
+}
+
+func (m *CallCandidateMsg) Render(t *Theme) string {
+	return ""
+}
+
+func (m *CallCandidateMsg) String() string {
+	return ""
+}
+
+func (m *CallCandidateMsg) Marshal(format OutputFormat) []byte {
+	switch format {
+	case JSON:
+		return jsonEvent{
+			ID:      m.ID(),
+			Time:    m.Timestamp().Format(time.RFC3339Nano),
+			Type:    "call-candidate",
+			From:    m.from.Name(),
+			To:      m.to.Name(),
+			CallID:  m.callID,
+			Payload: m.payload,
+		}.marshal()
+	case IRCv3:
+		prefix := fmt.Sprintf("%s!%s@ssh-chat", m.from.Name(), m.from.Name())
+		return ircLine(m.ID(), m.Timestamp().Format(time.RFC3339Nano), prefix, "CALLCANDIDATE", m.to.Name(), m.payload)
+	default:
+		return nil
+	}
+}
+
+// RenderFor renders the candidate for cfg. A non-CallCapable client never
+// sees ICE candidates at all, the same as Render/String always being blank
+// for it; a CallCapable client gets the structured payload it needs to feed
+// its ICE agent.
+func (m *CallCandidateMsg) RenderFor(cfg UserConfig) string {
+	if !cfg.CallCapable {
+		return ""
+	}
+	if s, ok := renderStructured(m, cfg); ok {
+		return s
+	}
+	return strings.TrimRight(string(m.Marshal(JSON)), "\n")
+}
+
+// CallTerminateMsg ends a call, whether hung up, rejected, or timed out.
+// Reason is a short machine-readable word such as "hangup" or "timeout".
+type CallTerminateMsg struct {
+	callMsg
+	reason string
+}
+
+func NewCallTerminateMsg(callID CallID, from, to *User, reason string) *CallTerminateMsg {
+	return &CallTerminateMsg{
+		callMsg: newCallMsg(callID, from, to, ""),
+		reason:  reason,
+	}
+}
+
+// Reason returns why the call ended, e.g. "hangup" or "timeout".
+func (m *CallTerminateMsg) Reason() string {
+	return m.reason
+}
+
+func (m *CallTerminateMsg) Render(t *Theme) string {
+	if t == nil {
+		return m.String()
+	}
+	return t.ColorSys(m.String())
+}
+
+func (m *CallTerminateMsg) String() string {
+	return fmt.Sprintf("-> call with %s ended (%s)", m.from.Name(), m.reason)
+}
+
+func (m *CallTerminateMsg) Marshal(format OutputFormat) []byte {
+	switch format {
+	case JSON:
+		return jsonEvent{
+			ID:     m.ID(),
+			Time:   m.Timestamp().Format(time.RFC3339Nano),
+			Type:   "call-terminate",
+			From:   m.from.Name(),
+			To:     m.to.Name(),
+			CallID: m.callID,
+			Reason: m.reason,
+		}.marshal()
+	case IRCv3:
+		prefix := fmt.Sprintf("%s!%s@ssh-chat", m.from.Name(), m.from.Name())
+		return ircLine(m.ID(), m.Timestamp().Format(time.RFC3339Nano), prefix, "CALLTERMINATE", m.to.Name(), m.reason)
+	default:
+		return nil
+	}
+}
+
+// RenderFor renders the termination for cfg, following the same
+// CallCapable-gated structured/plain-text split as CallOfferMsg.RenderFor.
+func (m *CallTerminateMsg) RenderFor(cfg UserConfig) string {
+	if !cfg.CallCapable {
+		return m.Render(cfg.Theme)
+	}
+	if s, ok := renderStructured(m, cfg); ok {
+		return s
+	}
+	return strings.TrimRight(string(m.Marshal(JSON)), "\n")
+}
+
+// CallRequestMsg is a parsed "/call <user>" command, naming the callee by
+// the name the caller typed. It carries no CallID yet, since that requires
+// resolving targetName to a *User, allocating one via NextCallID, and
+// recording it with CallTable.Start, all of which only the room can do.
+type CallRequestMsg struct {
+	Msg
+	from       *User
+	targetName string
+}
+
+func NewCallRequestMsg(targetName string, from *User) *CallRequestMsg {
+	return &CallRequestMsg{
+		Msg:        Msg{id: NextID(), timestamp: time.Now()},
+		from:       from,
+		targetName: targetName,
+	}
+}
+
+func (m *CallRequestMsg) From() *User {
+	return m.from
+}
+
+// TargetName returns the name of the user being called, as typed.
+func (m *CallRequestMsg) TargetName() string {
+	return m.targetName
+}
+
+// Render is blank: a CallRequestMsg is never itself shown to a user, only
+// resolved into a CallOfferMsg for display.
+func (m *CallRequestMsg) Render(t *Theme) string {
+	return ""
+}
+
+func (m *CallRequestMsg) String() string {
+	return ""
+}
+
+// HangupMsg is a parsed "/hangup" command, ending whichever call from is
+// currently a party to.
+type HangupMsg struct {
+	Msg
+	from *User
+}
+
+func NewHangupMsg(from *User) *HangupMsg {
+	return &HangupMsg{
+		Msg:  Msg{id: NextID(), timestamp: time.Now()},
+		from: from,
+	}
+}
+
+func (m *HangupMsg) From() *User {
+	return m.from
+}
+
+// Render is blank: a HangupMsg is never itself shown to a user, only
+// resolved into a CallTerminateMsg for display.
+func (m *HangupMsg) Render(t *Theme) string {
+	return ""
+}
+
+func (m *HangupMsg) String() string {
+	return ""
+}
+
+// CallTimeout is how long an unanswered CallOfferMsg stays active before
+// it is automatically terminated.
+const CallTimeout = 30 * time.Second
+
+// callParties is the pair of users a CallID was started between, so End
+// can release both sides given only one of them (e.g. whichever party
+// typed /hangup).
+type callParties struct {
+	from, to *User
+}
+
+// CallTable tracks the at-most-one active call each user is party to, so
+// the room can reject a /call to or from someone already on a call and can
+// time out an offer nobody answered.
+type CallTable struct {
+	sync.Mutex
+
+	byUser  map[*User]CallID
+	timers  map[CallID]*time.Timer
+	parties map[CallID]callParties
+}
+
+// NewCallTable creates an empty CallTable.
+func NewCallTable() *CallTable {
+	return &CallTable{
+		byUser:  make(map[*User]CallID),
+		timers:  make(map[CallID]*time.Timer),
+		parties: make(map[CallID]callParties),
+	}
+}
+
+// Busy reports whether u is already a party to an active call.
+func (c *CallTable) Busy(u *User) bool {
+	c.Lock()
+	defer c.Unlock()
+	_, ok := c.byUser[u]
+	return ok
+}
+
+// Active returns the call u is currently a party to, if any.
+func (c *CallTable) Active(u *User) (CallID, bool) {
+	c.Lock()
+	defer c.Unlock()
+	id, ok := c.byUser[u]
+	return id, ok
+}
+
+// Parties returns the two users callID was started between, if it's still
+// active.
+func (c *CallTable) Parties(callID CallID) (from, to *User, ok bool) {
+	c.Lock()
+	defer c.Unlock()
+	p, ok := c.parties[callID]
+	return p.from, p.to, ok
+}
+
+// Start records a new call between from and to, scheduling onTimeout to
+// run after CallTimeout if the call is not answered or ended first. It
+// reports false, taking no action, if either party is already busy.
+func (c *CallTable) Start(callID CallID, from, to *User, onTimeout func()) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if _, ok := c.byUser[from]; ok {
+		return false
+	}
+	if _, ok := c.byUser[to]; ok {
+		return false
+	}
+
+	c.byUser[from] = callID
+	c.byUser[to] = callID
+	c.parties[callID] = callParties{from: from, to: to}
+	c.timers[callID] = time.AfterFunc(CallTimeout, onTimeout)
+	return true
+}
+
+// Answer cancels the unanswered-offer timeout for callID. It has no effect
+// if the call has already ended or the timeout already fired.
+func (c *CallTable) Answer(callID CallID) {
+	c.Lock()
+	defer c.Unlock()
+	if timer, ok := c.timers[callID]; ok {
+		timer.Stop()
+	}
+}
+
+// End releases both participants so they can place or receive new calls,
+// and cancels any pending timeout.
+func (c *CallTable) End(callID CallID, from, to *User) {
+	c.Lock()
+	defer c.Unlock()
+
+	if timer, ok := c.timers[callID]; ok {
+		timer.Stop()
+		delete(c.timers, callID)
+	}
+	delete(c.parties, callID)
+	if id, ok := c.byUser[from]; ok && id == callID {
+		delete(c.byUser, from)
+	}
+	if id, ok := c.byUser[to]; ok && id == callID {
+		delete(c.byUser, to)
+	}
+}
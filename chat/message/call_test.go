@@ -0,0 +1,136 @@
+package message
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCallRenderUsesTheme(t *testing.T) {
+	theme := &Theme{}
+	alice := &User{name: "alice"}
+	bob := &User{name: "bob"}
+
+	tests := []Message{
+		NewCallOfferMsg(1, alice, bob, "sdp"),
+		NewCallAnswerMsg(1, bob, alice, "sdp"),
+		NewCallTerminateMsg(1, alice, bob, "hangup"),
+	}
+
+	for _, m := range tests {
+		if got, want := m.Render(theme), theme.ColorSys(m.String()); got != want {
+			t.Errorf("%T.Render(theme) = %q, want %q (theme.ColorSys applied)", m, got, want)
+		}
+		if got := m.Render(nil); got != m.String() {
+			t.Errorf("%T.Render(nil) = %q, want %q", m, got, m.String())
+		}
+	}
+}
+
+func TestCallTableEndReleasesBothParties(t *testing.T) {
+	alice := &User{name: "alice"}
+	bob := &User{name: "bob"}
+	table := NewCallTable()
+
+	callID := NextCallID()
+	if !table.Start(callID, alice, bob, func() {}) {
+		t.Fatalf("Start should succeed")
+	}
+	if !table.Busy(alice) || !table.Busy(bob) {
+		t.Fatalf("both parties should be busy once a call starts")
+	}
+
+	from, to, ok := table.Parties(callID)
+	if !ok || from != alice || to != bob {
+		t.Fatalf("Parties(%d) = %v, %v, %v, want alice, bob, true", callID, from, to, ok)
+	}
+
+	table.End(callID, from, to)
+	if table.Busy(alice) || table.Busy(bob) {
+		t.Errorf("End should release both parties, not just the one it's called with")
+	}
+}
+
+func TestCallRenderForFallsBackToPlainNoticeWhenNotCapable(t *testing.T) {
+	alice := &User{name: "alice"}
+	bob := &User{name: "bob"}
+
+	tests := []interface {
+		Message
+		RenderFor(cfg UserConfig) string
+	}{
+		NewCallOfferMsg(1, alice, bob, "sdp-offer"),
+		NewCallAnswerMsg(1, bob, alice, "sdp-answer"),
+		NewCallTerminateMsg(1, alice, bob, "hangup"),
+	}
+
+	for _, m := range tests {
+		cfg := UserConfig{Theme: &Theme{}}
+		if got, want := m.RenderFor(cfg), m.Render(cfg.Theme); got != want {
+			t.Errorf("%T.RenderFor(non-capable) = %q, want plain notice %q", m, got, want)
+		}
+	}
+
+	candidate := NewCallCandidateMsg(1, alice, bob, "ice-candidate")
+	if got := candidate.RenderFor(UserConfig{}); got != "" {
+		t.Errorf("CallCandidateMsg.RenderFor(non-capable) = %q, want empty", got)
+	}
+}
+
+func TestCallRenderForSurfacesPayloadWhenCapable(t *testing.T) {
+	alice := &User{name: "alice"}
+	bob := &User{name: "bob"}
+	cfg := UserConfig{CallCapable: true}
+
+	offer := NewCallOfferMsg(42, alice, bob, "sdp-offer")
+	out := offer.RenderFor(cfg)
+	var ev jsonEvent
+	if err := json.Unmarshal([]byte(out), &ev); err != nil {
+		t.Fatalf("CallOfferMsg.RenderFor(capable) = %q, not valid JSON: %v", out, err)
+	}
+	if ev.CallID != 42 || ev.Payload != "sdp-offer" || ev.From != "alice" || ev.To != "bob" {
+		t.Errorf("CallOfferMsg.RenderFor(capable) = %+v, want callID 42, payload sdp-offer, alice->bob", ev)
+	}
+
+	candidate := NewCallCandidateMsg(42, alice, bob, "ice-candidate")
+	out = candidate.RenderFor(cfg)
+	if err := json.Unmarshal([]byte(out), &ev); err != nil {
+		t.Fatalf("CallCandidateMsg.RenderFor(capable) = %q, not valid JSON: %v", out, err)
+	}
+	if ev.Payload != "ice-candidate" {
+		t.Errorf("CallCandidateMsg.RenderFor(capable) payload = %q, want ice-candidate", ev.Payload)
+	}
+
+	terminate := NewCallTerminateMsg(42, alice, bob, "hangup")
+	out = terminate.RenderFor(cfg)
+	if err := json.Unmarshal([]byte(out), &ev); err != nil {
+		t.Fatalf("CallTerminateMsg.RenderFor(capable) = %q, not valid JSON: %v", out, err)
+	}
+	if ev.Reason != "hangup" {
+		t.Errorf("CallTerminateMsg.RenderFor(capable) reason = %q, want hangup", ev.Reason)
+	}
+}
+
+func TestCallRenderForCapableUsesIRCv3WhenConfigured(t *testing.T) {
+	alice := &User{name: "alice"}
+	bob := &User{name: "bob"}
+	cfg := UserConfig{CallCapable: true, Format: IRCv3}
+
+	offer := NewCallOfferMsg(1, alice, bob, "sdp-offer")
+	out := offer.RenderFor(cfg)
+	if !strings.Contains(out, "CALLOFFER") || !strings.Contains(out, "sdp-offer") {
+		t.Errorf("CallOfferMsg.RenderFor(capable, IRCv3) = %q, want an IRCv3 CALLOFFER line carrying the payload", out)
+	}
+}
+
+func TestCallTableStartRejectsBusyParty(t *testing.T) {
+	alice := &User{name: "alice"}
+	bob := &User{name: "bob"}
+	carol := &User{name: "carol"}
+	table := NewCallTable()
+
+	table.Start(NextCallID(), alice, bob, func() {})
+	if table.Start(NextCallID(), alice, carol, func() {}) {
+		t.Errorf("Start should reject a caller already on a call")
+	}
+}
@@ -0,0 +1,55 @@
+package message
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseTargetedCommand turns a parsed /edit, /delete, /react, /call, or
+// /hangup command into its corresponding message type, so a MessageStore
+// or Calls can later locate what it targets. It returns ok=false for any
+// other command, so callers can fall back to returning cmd unchanged.
+func parseTargetedCommand(cmd *CommandMsg) (msg Message, ok bool) {
+	switch cmd.command {
+	case "/edit":
+		if len(cmd.args) < 2 {
+			return nil, false
+		}
+		id, err := strconv.ParseUint(cmd.args[0], 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		newBody := strings.Join(cmd.args[1:], " ")
+		return NewEditMsg(ID(id), newBody, cmd.from), true
+	case "/delete":
+		if len(cmd.args) != 1 {
+			return nil, false
+		}
+		id, err := strconv.ParseUint(cmd.args[0], 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		return NewDeleteMsg(ID(id), cmd.from), true
+	case "/react":
+		if len(cmd.args) != 2 {
+			return nil, false
+		}
+		id, err := strconv.ParseUint(cmd.args[0], 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		return NewReactionMsg(ID(id), cmd.args[1], cmd.from), true
+	case "/call":
+		if len(cmd.args) != 1 {
+			return nil, false
+		}
+		return NewCallRequestMsg(cmd.args[0], cmd.from), true
+	case "/hangup":
+		if len(cmd.args) != 0 {
+			return nil, false
+		}
+		return NewHangupMsg(cmd.from), true
+	default:
+		return nil, false
+	}
+}
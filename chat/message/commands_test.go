@@ -0,0 +1,51 @@
+package message
+
+import "testing"
+
+func TestParseInputTargetedCommands(t *testing.T) {
+	u := &User{}
+
+	tests := []struct {
+		name string
+		body string
+		want func(Message) bool
+	}{
+		{"edit", "/edit 42 hello there", func(m Message) bool {
+			e, ok := m.(*EditMsg)
+			return ok && e.TargetID() == 42 && e.NewBody() == "hello there"
+		}},
+		{"delete", "/delete 7", func(m Message) bool {
+			d, ok := m.(*DeleteMsg)
+			return ok && d.TargetID() == 7
+		}},
+		{"react", "/react 3 :+1:", func(m Message) bool {
+			r, ok := m.(*ReactionMsg)
+			return ok && r.TargetID() == 3 && r.Emoji() == ":+1:"
+		}},
+		{"call", "/call bob", func(m Message) bool {
+			c, ok := m.(*CallRequestMsg)
+			return ok && c.TargetName() == "bob"
+		}},
+		{"hangup", "/hangup", func(m Message) bool {
+			_, ok := m.(*HangupMsg)
+			return ok
+		}},
+		{"unrecognized command falls back", "/nope", func(m Message) bool {
+			_, ok := m.(*CommandMsg)
+			return ok
+		}},
+		{"edit with missing body is left as a CommandMsg", "/edit 42", func(m Message) bool {
+			_, ok := m.(*CommandMsg)
+			return ok
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseInput(tt.body, u, u)
+			if !tt.want(got) {
+				t.Errorf("ParseInput(%q) = %#v, did not match expectation", tt.body, got)
+			}
+		})
+	}
+}
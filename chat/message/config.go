@@ -0,0 +1,96 @@
+package message
+
+import "regexp"
+
+// Bel is the terminal bell character, appended to a rendered message when a
+// user's highlight pattern matches and bells are enabled.
+const Bel = "\a"
+
+// OutputFormat selects how messages are rendered for a given user: as
+// ANSI-colored text for a human at a terminal, or as a machine-readable
+// stream for a bot or bridge.
+type OutputFormat int
+
+const (
+	// Human is ANSI-colored, markdown-rendered text for an interactive
+	// terminal. It is the default and existing behavior.
+	Human OutputFormat = iota
+	// JSON emits one JSON object per message, newline-delimited.
+	JSON
+	// IRCv3 emits IRC protocol lines with message-tags, so ssh-chat can be
+	// driven by IRC-library bots.
+	IRCv3
+)
+
+func (f OutputFormat) String() string {
+	switch f {
+	case JSON:
+		return "json"
+	case IRCv3:
+		return "ircv3"
+	default:
+		return "human"
+	}
+}
+
+// UserConfig holds per-user rendering preferences.
+type UserConfig struct {
+	Highlight *regexp.Regexp
+	Bell      bool
+	Theme     *Theme
+	ApiMode   bool
+
+	// Format selects the output format used by RenderFor. It defaults to
+	// Human; ApiMode is equivalent to Format == JSON for compatibility with
+	// existing bots that only expect markdown to be skipped.
+	Format OutputFormat
+
+	// CallCapable reports whether the connected client understands
+	// CallOfferMsg and friends well enough to establish a WebRTC/Opus
+	// session. Clients that don't set it just see a plain notice when
+	// they're called.
+	CallCapable bool
+
+	// Formatter renders message bodies for this user. It defaults to
+	// LegacyFormatter when nil, so existing configs keep today's rendering
+	// until a user opts into CommonMarkFormatter or PlainFormatter.
+	Formatter Formatter
+
+	// HistoryOnJoin is how many backlog messages to replay when this user
+	// joins. Zero means "unset, use DefaultHistoryOnJoin"; a user who
+	// wants no replay at all sets it negative.
+	HistoryOnJoin int
+}
+
+// historyOnJoin resolves HistoryOnJoin, applying DefaultHistoryOnJoin when
+// unset and treating a negative value as "replay nothing".
+func (cfg UserConfig) historyOnJoin() int {
+	switch {
+	case cfg.HistoryOnJoin < 0:
+		return 0
+	case cfg.HistoryOnJoin == 0:
+		return DefaultHistoryOnJoin
+	default:
+		return cfg.HistoryOnJoin
+	}
+}
+
+// formatter returns cfg.Formatter, defaulting to LegacyFormatter.
+func (cfg *UserConfig) formatter() Formatter {
+	if cfg.Formatter != nil {
+		return cfg.Formatter
+	}
+	return LegacyFormatter{}
+}
+
+// format resolves the effective OutputFormat, honoring the legacy ApiMode
+// flag for callers that haven't migrated to Format yet.
+func (cfg UserConfig) format() OutputFormat {
+	if cfg.Format != Human {
+		return cfg.Format
+	}
+	if cfg.ApiMode {
+		return JSON
+	}
+	return Human
+}
@@ -0,0 +1,91 @@
+package message
+
+import (
+	"fmt"
+	"time"
+)
+
+// EditMsg is a request from a user to replace the body of an earlier
+// message they sent, identified by its ID.
+type EditMsg struct {
+	Msg
+	from     *User
+	targetID ID
+	newBody  string
+}
+
+func NewEditMsg(targetID ID, newBody string, from *User) *EditMsg {
+	return &EditMsg{
+		Msg: Msg{
+			id:        NextID(),
+			timestamp: time.Now(),
+		},
+		from:     from,
+		targetID: targetID,
+		newBody:  newBody,
+	}
+}
+
+func (m *EditMsg) From() *User {
+	return m.from
+}
+
+// TargetID returns the ID of the message being edited.
+func (m *EditMsg) TargetID() ID {
+	return m.targetID
+}
+
+// NewBody returns the replacement body for the target message.
+func (m *EditMsg) NewBody() string {
+	return m.newBody
+}
+
+func (m *EditMsg) Render(t *Theme) string {
+	if t == nil {
+		return m.String()
+	}
+	return t.ColorSys(m.String())
+}
+
+func (m *EditMsg) String() string {
+	return fmt.Sprintf("-> %s edited a message", m.from.Name())
+}
+
+// DeleteMsg is a request from a user to remove an earlier message they
+// sent, identified by its ID.
+type DeleteMsg struct {
+	Msg
+	from     *User
+	targetID ID
+}
+
+func NewDeleteMsg(targetID ID, from *User) *DeleteMsg {
+	return &DeleteMsg{
+		Msg: Msg{
+			id:        NextID(),
+			timestamp: time.Now(),
+		},
+		from:     from,
+		targetID: targetID,
+	}
+}
+
+func (m *DeleteMsg) From() *User {
+	return m.from
+}
+
+// TargetID returns the ID of the message being deleted.
+func (m *DeleteMsg) TargetID() ID {
+	return m.targetID
+}
+
+func (m *DeleteMsg) Render(t *Theme) string {
+	if t == nil {
+		return m.String()
+	}
+	return t.ColorSys(m.String())
+}
+
+func (m *DeleteMsg) String() string {
+	return fmt.Sprintf("-> %s deleted a message", m.from.Name())
+}
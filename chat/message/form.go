@@ -0,0 +1,122 @@
+package message
+
+import (
+	"strings"
+	"time"
+)
+
+// FieldType is the kind of input a form Field expects.
+type FieldType int
+
+const (
+	TextField FieldType = iota
+	ChoiceField
+	BoolField
+	SecretField
+)
+
+// Field describes one question in a FormMsg.
+type Field struct {
+	Name     string
+	Label    string
+	Type     FieldType
+	Required bool
+	// Options lists the valid answers for a ChoiceField.
+	Options []string
+}
+
+// FormMsg prompts a single user for the next unanswered field of a
+// multi-step command, such as /register or /ban. The client is expected to
+// render Field() and send the reply back as ordinary input, which the
+// room routes to the user's FormSession instead of treating it as room
+// speech.
+type FormMsg struct {
+	Msg
+	to    *User
+	title string
+	field Field
+}
+
+func NewFormMsg(title string, field Field, to *User) *FormMsg {
+	return &FormMsg{
+		Msg: Msg{
+			id:        NextID(),
+			timestamp: time.Now(),
+		},
+		to:    to,
+		title: title,
+		field: field,
+	}
+}
+
+func (m *FormMsg) To() *User {
+	return m.to
+}
+
+// Title names the command driving this form, e.g. "register".
+func (m *FormMsg) Title() string {
+	return m.title
+}
+
+// Field is the question being asked.
+func (m *FormMsg) Field() Field {
+	return m.field
+}
+
+func (m *FormMsg) Render(t *Theme) string {
+	label := m.field.Label
+	if m.field.Type == ChoiceField && len(m.field.Options) > 0 {
+		label += " (" + strings.Join(m.field.Options, "/") + ")"
+	}
+	if m.field.Required {
+		label += " [required]"
+	}
+	return "-> " + label
+}
+
+func (m *FormMsg) String() string {
+	return m.Render(nil)
+}
+
+// FormResponseMsg delivers a completed form's answers back to the command
+// handler that started it.
+type FormResponseMsg struct {
+	Msg
+	from   *User
+	title  string
+	values map[string]string
+}
+
+func NewFormResponseMsg(title string, values map[string]string, from *User) *FormResponseMsg {
+	return &FormResponseMsg{
+		Msg: Msg{
+			id:        NextID(),
+			timestamp: time.Now(),
+		},
+		from:   from,
+		title:  title,
+		values: values,
+	}
+}
+
+func (m *FormResponseMsg) From() *User {
+	return m.from
+}
+
+// Title names the command this response belongs to.
+func (m *FormResponseMsg) Title() string {
+	return m.title
+}
+
+// Values returns the field name to answer mapping collected from the user.
+func (m *FormResponseMsg) Values() map[string]string {
+	return m.values
+}
+
+func (m *FormResponseMsg) Render(t *Theme) string {
+	return "-> " + m.title + " form submitted"
+}
+
+func (m *FormResponseMsg) String() string {
+	return m.Render(nil)
+}
@@ -0,0 +1,42 @@
+package message
+
+import "testing"
+
+func TestFormMsgRenderShowsOptionsAndRequired(t *testing.T) {
+	bob := &User{name: "bob"}
+	field := Field{Name: "color", Label: "Favorite color", Type: ChoiceField, Required: true, Options: []string{"red", "blue"}}
+	m := NewFormMsg("register", field, bob)
+
+	want := "-> Favorite color (red/blue) [required]"
+	if got := m.Render(nil); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if m.Title() != "register" || m.To() != bob || m.Field().Name != field.Name {
+		t.Errorf("NewFormMsg accessors = %q, %v, %q, want register, bob, %q", m.Title(), m.To(), m.Field().Name, field.Name)
+	}
+}
+
+func TestFormMsgRenderOmitsOptionsAndRequiredWhenUnset(t *testing.T) {
+	bob := &User{name: "bob"}
+	m := NewFormMsg("register", Field{Name: "bio", Label: "Bio"}, bob)
+
+	if got, want := m.Render(nil), "-> Bio"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestFormResponseMsgCarriesValues(t *testing.T) {
+	alice := &User{name: "alice"}
+	values := map[string]string{"name": "Alice", "bio": "likes Go"}
+	m := NewFormResponseMsg("register", values, alice)
+
+	if m.From() != alice || m.Title() != "register" {
+		t.Errorf("NewFormResponseMsg accessors = %v, %q, want alice, register", m.From(), m.Title())
+	}
+	if got := m.Values(); got["name"] != "Alice" || got["bio"] != "likes Go" {
+		t.Errorf("Values() = %v, want the submitted answers", got)
+	}
+	if got, want := m.Render(nil), "-> register form submitted"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ircChannel is the IRCv3 channel name public messages are relayed under,
+// since ssh-chat itself has no concept of multiple channels.
+const ircChannel = "#ssh-chat"
+
+// jsonEvent is the wire shape written for OutputFormat JSON.
+type jsonEvent struct {
+	ID      ID       `json:"id"`
+	Time    string   `json:"time"`
+	Type    string   `json:"type"`
+	From    string   `json:"from,omitempty"`
+	To      string   `json:"to,omitempty"`
+	Body    string   `json:"body,omitempty"`
+	Command string   `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	CallID  CallID   `json:"call_id,omitempty"`
+	Payload string   `json:"payload,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+}
+
+func (e jsonEvent) marshal() []byte {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	return append(b, '\n')
+}
+
+// ircLineSanitizer strips the bytes that would let a value interpolated
+// into an ircLine forge a second protocol line: \r and \n delimit IRC
+// lines, and NUL is illegal in one.
+var ircLineSanitizer = strings.NewReplacer("\r", "", "\n", "", "\x00", "")
+
+// ircLine formats a single IRCv3 protocol line with message-tags for id and
+// time, e.g.:
+//
+//	@id=42;time=2024-05-10T19:22:00.000Z :alice!alice@ssh-chat PRIVMSG #ssh-chat :hello
+//
+// prefix, command, target, and trailing are user-influenced (display names,
+// message bodies), so each is stripped of \r, \n, and NUL before being
+// placed on the wire; otherwise a message containing "\r\n" could forge
+// arbitrary additional lines into every bot's IRCv3 stream. trailing also
+// has raw ESC bytes stripped via sanitizeANSI, the same as the Human
+// rendering path, so a message body can't smuggle terminal escape
+// sequences into a bot or bridge that prints IRCv3 trailing text straight
+// to a terminal.
+func ircLine(id ID, timestamp string, prefix, command, target, trailing string) []byte {
+	prefix = ircLineSanitizer.Replace(prefix)
+	command = ircLineSanitizer.Replace(command)
+	target = ircLineSanitizer.Replace(target)
+	trailing = ircLineSanitizer.Replace(sanitizeANSI(trailing))
+	return []byte(fmt.Sprintf("@id=%d;time=%s :%s %s %s :%s\r\n",
+		id, timestamp, prefix, command, target, trailing))
+}
+
+func (m PublicMsg) Marshal(format OutputFormat) []byte {
+	switch format {
+	case JSON:
+		return jsonEvent{
+			ID:   m.ID(),
+			Time: m.Timestamp().Format(time.RFC3339Nano),
+			Type: "public",
+			From: m.from.Name(),
+			Body: m.decoratedBody(),
+		}.marshal()
+	case IRCv3:
+		prefix := fmt.Sprintf("%s!%s@ssh-chat", m.from.Name(), m.from.Name())
+		return ircLine(m.ID(), m.Timestamp().Format(time.RFC3339Nano), prefix, "PRIVMSG", ircChannel, m.decoratedBody())
+	default:
+		return nil
+	}
+}
+
+func (m PrivateMsg) Marshal(format OutputFormat) []byte {
+	switch format {
+	case JSON:
+		return jsonEvent{
+			ID:   m.ID(),
+			Time: m.Timestamp().Format(time.RFC3339Nano),
+			Type: "private",
+			From: m.from.Name(),
+			To:   m.to.Name(),
+			Body: m.body,
+		}.marshal()
+	case IRCv3:
+		prefix := fmt.Sprintf("%s!%s@ssh-chat", m.from.Name(), m.from.Name())
+		return ircLine(m.ID(), m.Timestamp().Format(time.RFC3339Nano), prefix, "PRIVMSG", m.to.Name(), m.body)
+	default:
+		return nil
+	}
+}
+
+func (m *SystemMsg) Marshal(format OutputFormat) []byte {
+	switch format {
+	case JSON:
+		return jsonEvent{
+			ID:   m.ID(),
+			Time: m.Timestamp().Format(time.RFC3339Nano),
+			Type: "system",
+			To:   m.to.Name(),
+			Body: m.body,
+		}.marshal()
+	case IRCv3:
+		return ircLine(m.ID(), m.Timestamp().Format(time.RFC3339Nano), "ssh-chat.server", "NOTICE", m.to.Name(), m.body)
+	default:
+		return nil
+	}
+}
+
+func (m *AnnounceMsg) Marshal(format OutputFormat) []byte {
+	switch format {
+	case JSON:
+		return jsonEvent{
+			ID:   m.ID(),
+			Time: m.Timestamp().Format(time.RFC3339Nano),
+			Type: "announce",
+			Body: m.body,
+		}.marshal()
+	case IRCv3:
+		return ircLine(m.ID(), m.Timestamp().Format(time.RFC3339Nano), "ssh-chat.server", "NOTICE", ircChannel, m.body)
+	default:
+		return nil
+	}
+}
+
+func (m CommandMsg) Marshal(format OutputFormat) []byte {
+	if format != JSON {
+		return m.PublicMsg.Marshal(format)
+	}
+	return jsonEvent{
+		ID:      m.ID(),
+		Time:    m.Timestamp().Format(time.RFC3339Nano),
+		Type:    "command",
+		From:    m.from.Name(),
+		Command: m.command,
+		Args:    m.args,
+	}.marshal()
+}
+
+// renderStructured returns the structured-format rendering of m for cfg's
+// OutputFormat, or ("", false) if cfg calls for Human rendering.
+func renderStructured(m interface{ Marshal(OutputFormat) []byte }, cfg UserConfig) (string, bool) {
+	format := cfg.format()
+	if format == Human {
+		return "", false
+	}
+	return strings.TrimRight(string(m.Marshal(format)), "\n"), true
+}
@@ -0,0 +1,68 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestIrcLineStripsCRLFInjection(t *testing.T) {
+	line := ircLine(1, "2024-05-10T19:22:00.000Z", "evil\r\n:server NOTICE #ssh-chat :forged",
+		"PRIVMSG", "#ssh-chat", "hello\r\n:server NOTICE #ssh-chat :forged")
+
+	if bytes.Count(line, []byte("\r\n")) != 1 {
+		t.Errorf("ircLine(...) = %q, want exactly one trailing CRLF, no injected ones", line)
+	}
+	if bytes.Contains(line[:len(line)-2], []byte("\r")) || bytes.Contains(line[:len(line)-2], []byte("\n")) {
+		t.Errorf("ircLine(...) = %q, contains CR/LF before the final line terminator", line)
+	}
+}
+
+func TestIrcLineStripsRawANSIEscapes(t *testing.T) {
+	line := ircLine(1, "2024-05-10T19:22:00.000Z", "alice!alice@ssh-chat", "PRIVMSG", "#ssh-chat", "hello\x1b[31mworld")
+
+	if bytes.Contains(line, []byte("\x1b")) {
+		t.Errorf("ircLine(...) = %q, want raw ESC bytes stripped from trailing", line)
+	}
+}
+
+func TestPublicMsgMarshalJSONAndIRCv3AgreeOnDecoration(t *testing.T) {
+	u := &User{name: "alice"}
+	pm := NewPublicMsg("hello", u, u)
+	pm.AddReaction(":+1:")
+
+	var jsonDoc struct {
+		Body string `json:"body"`
+	}
+	if err := json.Unmarshal(pm.Marshal(JSON), &jsonDoc); err != nil {
+		t.Fatalf("unmarshal JSON event: %v", err)
+	}
+
+	ircv3 := string(pm.Marshal(IRCv3))
+
+	if jsonDoc.Body != pm.decoratedBody() {
+		t.Errorf("JSON body = %q, want decorated body %q", jsonDoc.Body, pm.decoratedBody())
+	}
+	if !bytes.Contains([]byte(ircv3), []byte(pm.decoratedBody())) {
+		t.Errorf("IRCv3 line %q does not contain decorated body %q", ircv3, pm.decoratedBody())
+	}
+}
+
+func TestPublicMsgRenderSelfHonorsFormat(t *testing.T) {
+	u := &User{name: "alice"}
+	pm := NewPublicMsg("hello", u, u)
+
+	cfg := UserConfig{Format: JSON}
+	out := pm.RenderSelf(cfg)
+
+	var jsonDoc struct {
+		Body string `json:"body"`
+		From string `json:"from"`
+	}
+	if err := json.Unmarshal([]byte(out), &jsonDoc); err != nil {
+		t.Fatalf("RenderSelf(format=json) = %q, not valid JSON: %v", out, err)
+	}
+	if jsonDoc.Body != "hello" || jsonDoc.From != "alice" {
+		t.Errorf("RenderSelf(format=json) = %+v, want body %q from %q", jsonDoc, "hello", "alice")
+	}
+}
@@ -0,0 +1,50 @@
+package message
+
+import "strings"
+
+// Formatter turns a raw message body into the string actually sent to the
+// terminal, given the viewer's theme for color choices.
+//
+// body has already had raw ESC bytes stripped by the caller, so a
+// Formatter only needs to avoid reintroducing literal \x1b sequences of
+// its own from user-controlled text (e.g. a URL or code span) rather than
+// from fixed strings it generates itself.
+type Formatter interface {
+	Format(body string, theme *Theme) string
+}
+
+// sanitizeANSI strips raw ESC (0x1b) bytes from user-supplied text before
+// any formatter sees it, so a message body can never smuggle its own
+// terminal escape sequences in.
+func sanitizeANSI(s string) string {
+	if !strings.ContainsRune(s, '\x1b') {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if r == '\x1b' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// PlainFormatter returns body unmodified, for API clients and for humans
+// who'd rather not see any styling at all.
+type PlainFormatter struct{}
+
+func (PlainFormatter) Format(body string, theme *Theme) string {
+	return body
+}
+
+// LegacyFormatter is ssh-chat's original hand-rolled markdown subset:
+// **bold**, __bold__, *italic*, _italic_, ~~strikethrough~~, `code`, and
+// [text](url) links. It's kept around for compatibility; CommonMarkFormatter
+// is the correctly-parsed replacement.
+type LegacyFormatter struct{}
+
+func (LegacyFormatter) Format(body string, theme *Theme) string {
+	return renderMarkdown(body)
+}
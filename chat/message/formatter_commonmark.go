@@ -0,0 +1,211 @@
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// CommonMarkFormatter renders a message body with a proper CommonMark
+// parser instead of the fragile prev/next-byte whitespace heuristics in
+// LegacyFormatter, so nested emphasis, escaped characters, and multi-line
+// quoted messages all behave the way they would in any other CommonMark
+// renderer. It supports fenced code blocks with language hints, inline
+// code spans, blockquotes, ordered/unordered lists, links rendered as
+// OSC-8 terminal hyperlinks, and images rendered as "[alt](url)" since a
+// terminal can't display them inline. Headings are collapsed to bold,
+// themed text rather than given their own visual hierarchy.
+type CommonMarkFormatter struct{}
+
+func (CommonMarkFormatter) Format(body string, theme *Theme) string {
+	source := []byte(body)
+	doc := goldmark.DefaultParser().Parse(text.NewReader(source))
+
+	w := &commonMarkWriter{source: source, theme: theme}
+	w.out = &w.buf
+	_ = ast.Walk(doc, w.visit)
+	return strings.TrimRight(w.buf.String(), "\n")
+}
+
+type commonMarkWriter struct {
+	source []byte
+	theme  *Theme
+	buf    strings.Builder
+	indent int
+
+	// out is where writes currently go: normally &buf, but swapped to a
+	// scratch builder for the duration of a heading so its text can be
+	// captured and themed as a whole once the heading closes.
+	out     *strings.Builder
+	heading strings.Builder
+	prevOut *strings.Builder
+
+	// lists tracks the list currently being rendered at each nesting
+	// level, so ListItem knows whether to number itself or bullet itself,
+	// and what number comes next.
+	lists []listCounter
+}
+
+// listCounter is the per-list state ListItem consults: whether the
+// enclosing *ast.List is ordered, and, if so, the number its next item
+// should use.
+type listCounter struct {
+	ordered bool
+	next    int
+}
+
+func (w *commonMarkWriter) visit(n ast.Node, entering bool) (ast.WalkStatus, error) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		if entering {
+			w.heading.Reset()
+			w.prevOut = w.out
+			w.out = &w.heading
+		} else {
+			w.out = w.prevOut
+			w.out.WriteString(w.colorHeading(w.heading.String()))
+			w.out.WriteString("\n\n")
+		}
+	case *ast.Paragraph:
+		if !entering {
+			w.out.WriteString("\n\n")
+		}
+	case *ast.Blockquote:
+		if entering {
+			w.writeIndented("\x1b[2m> ")
+		} else {
+			w.out.WriteString("\x1b[22m\n")
+		}
+	case *ast.List:
+		if entering {
+			w.indent++
+			w.lists = append(w.lists, listCounter{ordered: n.Ordered, next: n.Start})
+		} else {
+			w.indent--
+			w.lists = w.lists[:len(w.lists)-1]
+		}
+	case *ast.ListItem:
+		if entering {
+			if i := len(w.lists) - 1; i >= 0 && w.lists[i].ordered {
+				w.writeIndented(fmt.Sprintf("%d. ", w.lists[i].next))
+				w.lists[i].next++
+			} else {
+				w.writeIndented("- ")
+			}
+		} else {
+			w.out.WriteString("\n")
+		}
+	case *ast.FencedCodeBlock:
+		if entering {
+			lang := string(n.Language(w.source))
+			w.out.WriteString("\x1b[48;5;22m")
+			if lang != "" {
+				w.out.WriteString("[" + lang + "]\n")
+			}
+			for i := 0; i < n.Lines().Len(); i++ {
+				line := n.Lines().At(i)
+				w.out.Write(line.Value(w.source))
+			}
+			w.out.WriteString("\x1b[49m\n")
+			return ast.WalkSkipChildren, nil
+		}
+	case *ast.CodeBlock:
+		if entering {
+			w.out.WriteString("\x1b[48;5;22m")
+			for i := 0; i < n.Lines().Len(); i++ {
+				line := n.Lines().At(i)
+				w.out.Write(line.Value(w.source))
+			}
+			w.out.WriteString("\x1b[49m\n")
+			return ast.WalkSkipChildren, nil
+		}
+	case *ast.CodeSpan:
+		if entering {
+			w.out.WriteString("\x1b[48;5;22m")
+		} else {
+			w.out.WriteString("\x1b[49m")
+		}
+	case *ast.Emphasis:
+		code := "\x1b[3m"
+		reset := "\x1b[23m"
+		if n.Level >= 2 {
+			code, reset = "\x1b[1m", "\x1b[22m"
+		}
+		if entering {
+			w.out.WriteString(code)
+		} else {
+			w.out.WriteString(reset)
+		}
+	case *ast.Link:
+		if entering {
+			w.out.WriteString("\x1b]8;;" + string(n.Destination) + "\x1b\\")
+		} else {
+			w.out.WriteString("\x1b]8;;\x1b\\")
+		}
+	case *ast.Image:
+		if entering {
+			w.out.WriteString("[")
+			w.out.Write(n.Text(w.source))
+			w.out.WriteString("](" + string(n.Destination) + ")")
+			return ast.WalkSkipChildren, nil
+		}
+	case *ast.AutoLink:
+		if entering {
+			dest := string(n.URL(w.source))
+			w.out.WriteString("\x1b]8;;" + dest + "\x1b\\" + dest + "\x1b]8;;\x1b\\")
+			return ast.WalkSkipChildren, nil
+		}
+	case *ast.Text:
+		if entering {
+			w.out.Write(unescapeMarkdown(n.Segment.Value(w.source)))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				w.out.WriteString("\n")
+			}
+		}
+	case *ast.String:
+		if entering {
+			w.out.Write(n.Value)
+		}
+	}
+	return ast.WalkContinue, nil
+}
+
+// colorHeading wraps s in bold plus the theme's system color, matching the
+// bold+color treatment every other themed message type gets via
+// Theme.ColorSys.
+func (w *commonMarkWriter) colorHeading(s string) string {
+	if w.theme == nil {
+		return "\x1b[1m" + s + "\x1b[22m"
+	}
+	return "\x1b[1m" + w.theme.ColorSys(s) + "\x1b[22m"
+}
+
+// commonMarkEscapable is the ASCII punctuation CommonMark lets a
+// backslash escape (https://spec.commonmark.org/0.30/#backslash-escapes);
+// a backslash before anything else is left as a literal backslash.
+const commonMarkEscapable = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// unescapeMarkdown drops the backslash from a CommonMark backslash-escape
+// (e.g. "\*" -> "*"), since a Text node's segment still contains the raw
+// source bytes, escaping backslash included.
+func unescapeMarkdown(b []byte) []byte {
+	if !bytes.ContainsRune(b, '\\') {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' && i+1 < len(b) && strings.IndexByte(commonMarkEscapable, b[i+1]) >= 0 {
+			continue
+		}
+		out = append(out, b[i])
+	}
+	return out
+}
+
+func (w *commonMarkWriter) writeIndented(prefix string) {
+	w.out.WriteString(strings.Repeat("  ", w.indent) + prefix)
+}
@@ -0,0 +1,89 @@
+package message
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommonMarkFormatterColorsHeadings(t *testing.T) {
+	theme := &Theme{}
+	out := CommonMarkFormatter{}.Format("# Title", theme)
+
+	want := theme.ColorSys("Title")
+	if !strings.Contains(out, want) {
+		t.Errorf("Format(%q) = %q, want it to contain theme-colored heading text %q", "# Title", out, want)
+	}
+	if !strings.HasPrefix(out, "\x1b[1m") || !strings.Contains(out, "\x1b[22m") {
+		t.Errorf("Format(%q) = %q, want bold markers around the heading", "# Title", out)
+	}
+}
+
+func TestCommonMarkFormatterHeadingWithoutThemeStillBolds(t *testing.T) {
+	out := CommonMarkFormatter{}.Format("# Title", nil)
+	if !strings.Contains(out, "Title") || !strings.Contains(out, "\x1b[1m") {
+		t.Errorf("Format(%q) with nil theme = %q, want plain bold heading", "# Title", out)
+	}
+}
+
+func TestCommonMarkFormatterResolvesBackslashEscapes(t *testing.T) {
+	out := CommonMarkFormatter{}.Format(`escaped \*not emphasis\*`, nil)
+	want := "escaped *not emphasis*"
+	if out != want {
+		t.Errorf("Format(%q) = %q, want %q", `escaped \*not emphasis\*`, out, want)
+	}
+	if strings.Contains(out, "\\") {
+		t.Errorf("Format(%q) = %q, backslash should not survive", `escaped \*not emphasis\*`, out)
+	}
+}
+
+func TestCommonMarkFormatterEmphasis(t *testing.T) {
+	out := CommonMarkFormatter{}.Format("*em* and **strong**", nil)
+	if !strings.Contains(out, "\x1b[3mem\x1b[23m") {
+		t.Errorf("Format(emphasis) = %q, want italic markers around %q", out, "em")
+	}
+	if !strings.Contains(out, "\x1b[1mstrong\x1b[22m") {
+		t.Errorf("Format(strong) = %q, want bold markers around %q", out, "strong")
+	}
+}
+
+func TestCommonMarkFormatterBlockquote(t *testing.T) {
+	out := CommonMarkFormatter{}.Format("> quoted text", nil)
+	if !strings.Contains(out, "quoted text") || !strings.Contains(out, "\x1b[2m") {
+		t.Errorf("Format(blockquote) = %q, want dimmed quoted text", out)
+	}
+}
+
+func TestCommonMarkFormatterUnorderedList(t *testing.T) {
+	out := CommonMarkFormatter{}.Format("- first\n- second\n- third", nil)
+	for _, want := range []string{"- first", "- second", "- third"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Format(unordered list) = %q, want it to contain %q", out, want)
+		}
+	}
+}
+
+func TestCommonMarkFormatterOrderedList(t *testing.T) {
+	out := CommonMarkFormatter{}.Format("1. first\n2. second\n3. third", nil)
+	for _, want := range []string{"1. first", "2. second", "3. third"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Format(ordered list) = %q, want it to contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "- ") {
+		t.Errorf("Format(ordered list) = %q, should not fall back to unordered bullets", out)
+	}
+}
+
+func TestCommonMarkFormatterOrderedListHonorsStart(t *testing.T) {
+	out := CommonMarkFormatter{}.Format("5. fifth\n6. sixth", nil)
+	if !strings.Contains(out, "5. fifth") || !strings.Contains(out, "6. sixth") {
+		t.Errorf("Format(ordered list, start=5) = %q, want numbering to start at 5", out)
+	}
+}
+
+func TestCommonMarkFormatterFencedCodeBlock(t *testing.T) {
+	out := CommonMarkFormatter{}.Format("```\ncode line\n```", nil)
+	if !strings.Contains(out, "code line") {
+		t.Errorf("Format(fenced code) = %q, want it to contain %q", out, "code line")
+	}
+}
@@ -0,0 +1,91 @@
+package message
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultHistoryOnJoin is how many backlog messages a user is replayed
+// when they join, unless their UserConfig.HistoryOnJoin overrides it.
+const DefaultHistoryOnJoin = 20
+
+// renderForer is implemented by message types with a per-user RenderFor,
+// e.g. PublicMsg. HistoryMsg uses it when available so a replayed message
+// still gets format-aware rendering, falling back to plain Render.
+type renderForer interface {
+	RenderFor(cfg UserConfig) string
+}
+
+// HistoryMsg wraps an earlier message being replayed to a user, whether
+// from /history or automatically on join, the ssh-chat analogue of
+// XEP-0313 MAM replay. It renders with a dim timestamp prefix so backfilled
+// lines are visually distinct from live traffic.
+type HistoryMsg struct {
+	Message
+	delay time.Time
+}
+
+// NewHistoryMsg wraps m for replay, recording when it was originally sent.
+func NewHistoryMsg(m Message, delay time.Time) *HistoryMsg {
+	return &HistoryMsg{Message: m, delay: delay}
+}
+
+// Delay returns the original message's send time.
+func (m *HistoryMsg) Delay() time.Time {
+	return m.delay
+}
+
+func (m *HistoryMsg) prefix(dim bool) string {
+	ts := m.delay.Format("2006-01-02 15:04")
+	if dim {
+		return "\x1b[2m[" + ts + "]\x1b[22m "
+	}
+	return "[" + ts + "] "
+}
+
+func (m *HistoryMsg) Render(t *Theme) string {
+	return m.prefix(t != nil) + m.Message.Render(t)
+}
+
+// RenderFor renders the wrapped message for cfg, preferring its own
+// RenderFor if it has one so format-aware types stay format-aware.
+func (m *HistoryMsg) RenderFor(cfg UserConfig) string {
+	if rf, ok := m.Message.(renderForer); ok {
+		return m.prefix(cfg.Theme != nil) + rf.RenderFor(cfg)
+	}
+	return m.Render(cfg.Theme)
+}
+
+func (m *HistoryMsg) String() string {
+	return m.prefix(false) + m.Message.String()
+}
+
+// historyLine is a minimal, self-contained Message reconstructed from a
+// persisted history record. Unlike PublicMsg it doesn't need a live *User,
+// since the sender may have long since disconnected by the time a backlog
+// entry is replayed.
+type historyLine struct {
+	Msg
+	from string
+}
+
+// NewHistoryLine reconstructs a Message for replay from a persisted
+// history record.
+func NewHistoryLine(id ID, from, body string, timestamp time.Time) Message {
+	return &historyLine{
+		Msg: Msg{
+			id:        id,
+			body:      body,
+			timestamp: timestamp,
+		},
+		from: from,
+	}
+}
+
+func (m *historyLine) String() string {
+	return fmt.Sprintf("%s: %s", m.from, m.body)
+}
+
+func (m *historyLine) Render(t *Theme) string {
+	return m.String()
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kenshaw/emoji"
@@ -15,6 +17,19 @@ type Message interface {
 	String() string
 	Command() string
 	Timestamp() time.Time
+	ID() ID
+}
+
+// ID identifies a message for later reference, e.g. by an edit or a
+// reaction. IDs are assigned in increasing order as messages are
+// constructed, so they can also be used to order messages.
+type ID uint64
+
+var lastID uint64
+
+// NextID returns a new, monotonically increasing message ID.
+func NextID() ID {
+	return ID(atomic.AddUint64(&lastID, 1))
 }
 
 type MessageTo interface {
@@ -32,6 +47,9 @@ func ParseInput(body string, from *User, originalFrom *User) Message {
 	m := NewPublicMsg(body, from, originalFrom)
 	cmd, isCmd := m.ParseCommand()
 	if isCmd {
+		if targeted, ok := parseTargetedCommand(cmd); ok {
+			return targeted
+		}
 		return cmd
 	}
 	if strings.HasPrefix(strings.TrimLeft(m.body, " "), "/") {
@@ -42,6 +60,7 @@ func ParseInput(body string, from *User, originalFrom *User) Message {
 
 // Msg is a base type for other message types.
 type Msg struct {
+	id        ID
 	body      string
 	timestamp time.Time
 	// TODO: themeCache *map[*Theme]string
@@ -49,6 +68,7 @@ type Msg struct {
 
 func NewMsg(body string) *Msg {
 	return &Msg{
+		id:        NextID(),
 		body:      body,
 		timestamp: time.Now(),
 	}
@@ -73,21 +93,35 @@ func (m Msg) Timestamp() time.Time {
 	return m.timestamp
 }
 
+// ID returns the message's stable identifier.
+func (m Msg) ID() ID {
+	return m.id
+}
+
 // PublicMsg is any message from a user sent to the room.
 type PublicMsg struct {
 	Msg
 	from *User
 	originalFrom *User
+	edited bool
+	reactions *ReactionTally
+	// mu guards body, edited, and reactions against concurrent ApplyEdit
+	// and ApplyReaction calls from MessageStore. It's a pointer so that
+	// it's shared across the value copies PublicMsg is passed around as
+	// (e.g. by CommandMsg, PrivateMsg, and RenderFor/RenderSelf).
+	mu *sync.Mutex
 }
 
 func NewPublicMsg(body string, from *User, originalFrom *User) PublicMsg {
 	return PublicMsg{
 		Msg: Msg{
+			id:        NextID(),
 			body:      body,
 			timestamp: time.Now(),
 		},
 		from: from,
 		originalFrom: originalFrom,
+		mu: &sync.Mutex{},
 	}
 }
 
@@ -99,6 +133,50 @@ func (m PublicMsg) OriginalFrom() *User {
 	return m.originalFrom
 }
 
+// SetBody replaces the message body in place and marks it as edited. It is
+// used by the room to apply an EditMsg to the original message it targets.
+func (m *PublicMsg) SetBody(body string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.body = body
+	m.edited = true
+}
+
+// Edited reports whether the message has been modified by an EditMsg.
+func (m PublicMsg) Edited() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.edited
+}
+
+// Body returns the message's current text, without the "from: " prefix
+// String adds or the "(edited)"/reaction decoration decoratedBody adds.
+func (m PublicMsg) Body() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.body
+}
+
+// AddReaction records an emoji reaction against this message.
+func (m *PublicMsg) AddReaction(emoji string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reactions == nil {
+		m.reactions = NewReactionTally()
+	}
+	m.reactions.Add(emoji)
+}
+
+// RemoveReaction un-records an emoji reaction against this message.
+func (m *PublicMsg) RemoveReaction(emoji string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reactions == nil {
+		return
+	}
+	m.reactions.Remove(emoji)
+}
+
 func (m PublicMsg) ParseCommand() (*CommandMsg, bool) {
 	// Check if the message is a command
 	if !strings.HasPrefix(m.body, "/") {
@@ -284,7 +362,7 @@ func renderMarkdown(s string) string {
 
 func renderMessageFor(prefix string, u *User, sep string, body string, t *Theme, cfg *UserConfig, doHighlight bool) string {
 	if cfg != nil && !cfg.ApiMode {
-		body = renderMarkdown(body)
+		body = cfg.formatter().Format(sanitizeANSI(body), t)
 		if t != nil && doHighlight {
 			newBody := cfg.Highlight.ReplaceAllString(body, t.Highlight("${1}"))
 			if newBody != body {
@@ -303,22 +381,48 @@ func renderMessageFor(prefix string, u *User, sep string, body string, t *Theme,
 }
 
 
+// decoratedBody appends an "(edited)" marker and any reaction tally to the
+// message body, as shown to viewers regardless of output format.
+func (m PublicMsg) decoratedBody() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	body := m.body
+	if m.edited {
+		body += " (edited)"
+	}
+	if m.reactions != nil {
+		if s := m.reactions.String(); s != "" {
+			body += "  " + s
+		}
+	}
+	return body
+}
+
 func (m PublicMsg) Render(t *Theme) string {
-	return renderMessageFor("", m.from, ": ", m.body, t, nil, true)
+	return renderMessageFor("", m.from, ": ", m.decoratedBody(), t, nil, true)
 }
 
 // RenderFor renders the message for other users to see.
 func (m PublicMsg) RenderFor(cfg UserConfig) string {
-	return renderMessageFor("", m.from, ": ", m.body, cfg.Theme, &cfg, true)
+	if s, ok := renderStructured(m, cfg); ok {
+		return s
+	}
+	return renderMessageFor("", m.from, ": ", m.decoratedBody(), cfg.Theme, &cfg, true)
 }
 
 // RenderSelf renders the message for when it's echoing your own message.
 func (m PublicMsg) RenderSelf(cfg UserConfig) string {
-	return renderMessageFor("[", m.from, "] ", m.body, cfg.Theme, &cfg, false)
+	if s, ok := renderStructured(m, cfg); ok {
+		return s
+	}
+	return renderMessageFor("[", m.from, "] ", m.decoratedBody(), cfg.Theme, &cfg, false)
 }
 
 func (m PublicMsg) String() string {
-	return fmt.Sprintf("%s: %s", m.from.Name(), m.body)
+	m.mu.Lock()
+	body := m.body
+	m.mu.Unlock()
+	return fmt.Sprintf("%s: %s", m.from.Name(), body)
 }
 
 // EmoteMsg is a /me message sent to the room.
@@ -331,6 +435,7 @@ type EmoteMsg struct {
 func NewEmoteMsg(body string, from *User, originalFrom *User) *EmoteMsg {
 	return &EmoteMsg{
 		Msg: Msg{
+			id:        NextID(),
 			body:      body,
 			timestamp: time.Now(),
 		},
@@ -389,6 +494,9 @@ func (m PrivateMsg) Render(t *Theme) string {
 }
 
 func (m PrivateMsg) RenderFor(cfg UserConfig) string {
+	if s, ok := renderStructured(m, cfg); ok {
+		return s
+	}
 	return renderMessageFor("[PM from ", m.from, "] ", m.body, cfg.Theme, &cfg, true)
 }
 
@@ -406,6 +514,7 @@ type SystemMsg struct {
 func NewSystemMsg(body string, to *User) *SystemMsg {
 	return &SystemMsg{
 		Msg: Msg{
+			id:        NextID(),
 			body:      body,
 			timestamp: time.Now(),
 		},
@@ -420,6 +529,15 @@ func (m *SystemMsg) Render(t *Theme) string {
 	return t.ColorSys(m.String())
 }
 
+// RenderFor renders the message using the recipient's preferred output
+// format, falling back to Render for Human.
+func (m *SystemMsg) RenderFor(cfg UserConfig) string {
+	if s, ok := renderStructured(m, cfg); ok {
+		return s
+	}
+	return m.Render(cfg.Theme)
+}
+
 func (m *SystemMsg) String() string {
 	return fmt.Sprintf("-> %s", m.body)
 }
@@ -437,6 +555,7 @@ type AnnounceMsg struct {
 func NewAnnounceMsg(body string) *AnnounceMsg {
 	return &AnnounceMsg{
 		Msg: Msg{
+			id:        NextID(),
 			body:      body,
 			timestamp: time.Now(),
 		},
@@ -450,6 +569,15 @@ func (m AnnounceMsg) Render(t *Theme) string {
 	return t.ColorSys(m.String())
 }
 
+// RenderFor renders the message using the recipient's preferred output
+// format, falling back to Render for Human.
+func (m AnnounceMsg) RenderFor(cfg UserConfig) string {
+	if s, ok := renderStructured(&m, cfg); ok {
+		return s
+	}
+	return m.Render(cfg.Theme)
+}
+
 func (m AnnounceMsg) String() string {
 	return fmt.Sprintf(" * %s", m.body)
 }
@@ -462,6 +590,7 @@ type MOTDMsg struct {
 func NewMOTDMsg(body string) *MOTDMsg {
 	return &MOTDMsg{
 		Msg: Msg{
+			id:        NextID(),
 			body:      body,
 			timestamp: time.Now(),
 		},
@@ -0,0 +1,101 @@
+package message
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReactionMsg is a request from a user to add an emoji reaction to an
+// earlier message, identified by its ID.
+type ReactionMsg struct {
+	Msg
+	from     *User
+	targetID ID
+	emoji    string
+}
+
+func NewReactionMsg(targetID ID, emoji string, from *User) *ReactionMsg {
+	return &ReactionMsg{
+		Msg: Msg{
+			id:        NextID(),
+			timestamp: time.Now(),
+		},
+		from:     from,
+		targetID: targetID,
+		emoji:    emoji,
+	}
+}
+
+func (m *ReactionMsg) From() *User {
+	return m.from
+}
+
+// TargetID returns the ID of the message being reacted to.
+func (m *ReactionMsg) TargetID() ID {
+	return m.targetID
+}
+
+// Emoji returns the reaction emoji, e.g. "👍".
+func (m *ReactionMsg) Emoji() string {
+	return m.emoji
+}
+
+func (m *ReactionMsg) Render(t *Theme) string {
+	if t == nil {
+		return m.String()
+	}
+	return t.ColorSys(m.String())
+}
+
+func (m *ReactionMsg) String() string {
+	return fmt.Sprintf("-> %s reacted %s", m.from.Name(), m.emoji)
+}
+
+// ReactionTally renders the aggregated reaction counts for a message, e.g.
+// "👍 x3 😂 x1", in the order the emoji were first used.
+type ReactionTally struct {
+	order []string
+	count map[string]int
+}
+
+func NewReactionTally() *ReactionTally {
+	return &ReactionTally{
+		count: make(map[string]int),
+	}
+}
+
+// Add records a reaction, returning the emoji's new count.
+func (rt *ReactionTally) Add(emoji string) int {
+	if _, ok := rt.count[emoji]; !ok {
+		rt.order = append(rt.order, emoji)
+	}
+	rt.count[emoji]++
+	return rt.count[emoji]
+}
+
+// Remove un-records a reaction, dropping the emoji once its count reaches
+// zero.
+func (rt *ReactionTally) Remove(emoji string) {
+	if rt.count[emoji] <= 1 {
+		delete(rt.count, emoji)
+		for i, e := range rt.order {
+			if e == emoji {
+				rt.order = append(rt.order[:i], rt.order[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	rt.count[emoji]--
+}
+
+func (rt *ReactionTally) String() string {
+	s := ""
+	for i, emoji := range rt.order {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("%s x%d", emoji, rt.count[emoji])
+	}
+	return s
+}
@@ -0,0 +1,106 @@
+package message
+
+import "sync"
+
+// MessageStoreSize is the default number of recent messages a MessageStore
+// retains for lookup by ID.
+const MessageStoreSize = 200
+
+// MessageStore keeps a bounded ring of the most recently sent PublicMsg
+// messages, indexed by ID, so that later EditMsg, DeleteMsg, and
+// ReactionMsg requests can locate and mutate the message they target.
+// Once a message falls out of the ring it can no longer be edited or
+// reacted to.
+type MessageStore struct {
+	sync.Mutex
+
+	size int
+	ring []*PublicMsg
+	byID map[ID]*PublicMsg
+}
+
+// NewMessageStore creates a MessageStore retaining up to size messages.
+func NewMessageStore(size int) *MessageStore {
+	if size <= 0 {
+		size = MessageStoreSize
+	}
+	return &MessageStore{
+		size: size,
+		byID: make(map[ID]*PublicMsg),
+	}
+}
+
+// Add records m so it can later be looked up by its ID, evicting the
+// oldest retained message if the store is full.
+func (s *MessageStore) Add(m *PublicMsg) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.ring = append(s.ring, m)
+	s.byID[m.ID()] = m
+
+	if len(s.ring) > s.size {
+		oldest := s.ring[0]
+		s.ring = s.ring[1:]
+		delete(s.byID, oldest.ID())
+	}
+}
+
+// Get returns the retained message with the given ID, if any.
+func (s *MessageStore) Get(id ID) (*PublicMsg, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	m, ok := s.byID[id]
+	return m, ok
+}
+
+// ApplyEdit rewrites the body of the message targeted by e, returning the
+// edited message so the caller can re-render and broadcast it. It reports
+// false if the target message is no longer retained, or does not belong to
+// the editor.
+func (s *MessageStore) ApplyEdit(e *EditMsg) (*PublicMsg, bool) {
+	m, ok := s.Get(e.TargetID())
+	if !ok || m.From() != e.From() {
+		return nil, false
+	}
+	m.SetBody(e.NewBody())
+	return m, true
+}
+
+// ApplyDelete removes the message targeted by d from the store, returning
+// it so the caller can announce its removal. It reports false if the
+// target message is no longer retained, or does not belong to the
+// deleter.
+func (s *MessageStore) ApplyDelete(d *DeleteMsg) (*PublicMsg, bool) {
+	s.Lock()
+	m, ok := s.byID[d.TargetID()]
+	s.Unlock()
+	if !ok || m.From() != d.From() {
+		return nil, false
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	delete(s.byID, d.TargetID())
+	for i, rm := range s.ring {
+		if rm.ID() == d.TargetID() {
+			s.ring = append(s.ring[:i], s.ring[i+1:]...)
+			break
+		}
+	}
+	return m, true
+}
+
+// ApplyReaction adds the reaction described by r to its target message,
+// returning the updated message so the caller can re-render and broadcast
+// it. A repeated reaction is counted again; it never removes one. It
+// reports false if the target message is no longer retained.
+func (s *MessageStore) ApplyReaction(r *ReactionMsg) (*PublicMsg, bool) {
+	m, ok := s.Get(r.TargetID())
+	if !ok {
+		return nil, false
+	}
+	m.AddReaction(r.Emoji())
+	return m, true
+}
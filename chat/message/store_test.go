@@ -0,0 +1,74 @@
+package message
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMessageStoreApplyReactionConcurrent(t *testing.T) {
+	u := &User{}
+	pm := NewPublicMsg("hi", u, u)
+	store := NewMessageStore(10)
+	store.Add(&pm)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.ApplyReaction(NewReactionMsg(pm.ID(), "+1", u))
+		}()
+	}
+	wg.Wait()
+
+	got, ok := store.Get(pm.ID())
+	if !ok {
+		t.Fatalf("message not found after concurrent reactions")
+	}
+	if got.reactions == nil || got.reactions.count["+1"] != n {
+		t.Errorf("reaction count = %v, want %d", got.reactions, n)
+	}
+}
+
+func TestMessageStoreApplyEditRequiresOwner(t *testing.T) {
+	alice := &User{}
+	bob := &User{}
+	pm := NewPublicMsg("hi", alice, alice)
+	store := NewMessageStore(10)
+	store.Add(&pm)
+
+	if _, ok := store.ApplyEdit(NewEditMsg(pm.ID(), "nope", bob)); ok {
+		t.Errorf("ApplyEdit by non-owner should fail")
+	}
+
+	got, ok := store.ApplyEdit(NewEditMsg(pm.ID(), "edited", alice))
+	if !ok || got.body != "edited" {
+		t.Errorf("ApplyEdit by owner should succeed, got %+v ok=%v", got, ok)
+	}
+	if !got.Edited() {
+		t.Errorf("edited message should report Edited() == true")
+	}
+}
+
+func TestMessageStoreApplyDeleteEvictsFromRing(t *testing.T) {
+	u := &User{}
+	pm := NewPublicMsg("hi", u, u)
+	store := NewMessageStore(10)
+	store.Add(&pm)
+
+	if _, ok := store.ApplyDelete(NewDeleteMsg(pm.ID(), u)); !ok {
+		t.Fatalf("ApplyDelete should succeed")
+	}
+	if _, ok := store.Get(pm.ID()); ok {
+		t.Errorf("deleted message should no longer be retained")
+	}
+}
+
+func TestMessageStoreApplyEditUnknownTarget(t *testing.T) {
+	u := &User{}
+	store := NewMessageStore(10)
+	if _, ok := store.ApplyEdit(NewEditMsg(999, "nope", u)); ok {
+		t.Errorf("ApplyEdit of an unknown target should fail")
+	}
+}
@@ -0,0 +1,65 @@
+package chat
+
+import (
+	"fmt"
+
+	"github.com/habrachat/ssh-chat/chat/message"
+)
+
+// Messages is the room-level home for chat/message's MessageStore: it
+// remembers recently sent room speech and applies the EditMsg, DeleteMsg,
+// and ReactionMsg that message.ParseInput produces from /edit, /delete,
+// and /react, the way FormSessions is the room-level home for FormMsg.
+type Messages struct {
+	store   *message.MessageStore
+	history *History
+}
+
+// NewMessages creates a dispatcher backed by a MessageStore retaining up
+// to size recent messages. history is optional (nil is fine) and, if set,
+// is kept in sync with edits and deletions so replay and join-backfill
+// don't show stale or removed content.
+func NewMessages(size int, history *History) *Messages {
+	return &Messages{store: message.NewMessageStore(size), history: history}
+}
+
+// Record keeps track of a newly sent public message so it can later be
+// edited, deleted, or reacted to.
+func (ms *Messages) Record(m *message.PublicMsg) {
+	ms.store.Add(m)
+}
+
+// Handle applies cmd to the message it targets and returns the message to
+// broadcast to the room. It returns ok=false if cmd isn't an EditMsg,
+// DeleteMsg, or ReactionMsg, or if its target can no longer be found (e.g.
+// it aged out of the store).
+func (ms *Messages) Handle(cmd message.Message) (message.Message, bool) {
+	switch m := cmd.(type) {
+	case *message.EditMsg:
+		target, ok := ms.store.ApplyEdit(m)
+		if !ok {
+			return nil, false
+		}
+		if ms.history != nil {
+			ms.history.Update(target.ID(), target.Body())
+		}
+		return target, true
+	case *message.DeleteMsg:
+		target, ok := ms.store.ApplyDelete(m)
+		if !ok {
+			return nil, false
+		}
+		if ms.history != nil {
+			ms.history.Delete(target.ID())
+		}
+		return message.NewAnnounceMsg(fmt.Sprintf("%s deleted a message", target.From().Name())), true
+	case *message.ReactionMsg:
+		target, ok := ms.store.ApplyReaction(m)
+		if !ok {
+			return nil, false
+		}
+		return target, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/habrachat/ssh-chat/chat/message"
+)
+
+func TestMessagesHandleEdit(t *testing.T) {
+	alice := &message.User{}
+	ms := NewMessages(10, nil)
+
+	pm := message.NewPublicMsg("hello", alice, alice)
+	ms.Record(&pm)
+
+	edit := message.NewEditMsg(pm.ID(), "hello world", alice)
+	result, ok := ms.Handle(edit)
+	if !ok {
+		t.Fatalf("Handle(edit) not ok")
+	}
+	got, ok := result.(*message.PublicMsg)
+	if !ok {
+		t.Fatalf("result is %T, want *message.PublicMsg", result)
+	}
+	if !got.Edited() {
+		t.Errorf("edited message not marked as edited")
+	}
+}
+
+func TestMessagesHandleReact(t *testing.T) {
+	alice := &message.User{}
+	bob := &message.User{}
+	ms := NewMessages(10, nil)
+
+	pm := message.NewPublicMsg("hello", alice, alice)
+	ms.Record(&pm)
+	before := pm.Render(nil)
+
+	react := message.NewReactionMsg(pm.ID(), ":+1:", bob)
+	if _, ok := ms.Handle(react); !ok {
+		t.Fatalf("Handle(react) not ok")
+	}
+
+	if after := pm.Render(nil); after == before {
+		t.Errorf("reaction tally not reflected in render: before=%q after=%q", before, after)
+	}
+}
+
+func TestMessagesHandleUnknownTarget(t *testing.T) {
+	ms := NewMessages(10, nil)
+	edit := message.NewEditMsg(999, "nope", &message.User{})
+	if _, ok := ms.Handle(edit); ok {
+		t.Errorf("Handle(edit) for an unknown target should return ok=false")
+	}
+}
+
+func TestMessagesHandleUnrecognizedMessage(t *testing.T) {
+	ms := NewMessages(10, nil)
+	if _, ok := ms.Handle(message.NewAnnounceMsg("hi")); ok {
+		t.Errorf("Handle should return ok=false for a message type it doesn't dispatch")
+	}
+}